@@ -0,0 +1,194 @@
+// Package desktop indexes Freedesktop .desktop application entries
+// alongside the $PATH executables yegonesh already scans, per the Desktop
+// Entry specification.
+package desktop
+
+import (
+	"bufio"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Entry is a parsed, filtered .desktop application entry. Name is what's
+// shown to the user; Exec is the field-code-expanded argv, ready to hand
+// to exec.Command directly — kept as a slice rather than a joined string
+// so launchCommand never has to re-tokenize it.
+type Entry struct {
+	Name string
+	Exec []string
+}
+
+// applicationDirs returns the XDG application directories to search, most
+// specific first, per the XDG Base Directory and Desktop Entry specs.
+func applicationDirs() []string {
+	var dirs []string
+
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		dataHome = filepath.Join(os.Getenv("HOME"), ".local/share")
+	}
+	dirs = append(dirs, filepath.Join(dataHome, "applications"))
+
+	// Per spec, $XDG_DATA_DIRS defaults to /usr/local/share:/usr/share,
+	// which is where /usr/share/applications comes from.
+	dataDirs := os.Getenv("XDG_DATA_DIRS")
+	if dataDirs == "" {
+		dataDirs = "/usr/local/share:/usr/share"
+	}
+	for _, dir := range strings.Split(dataDirs, ":") {
+		if dir != "" {
+			dirs = append(dirs, filepath.Join(dir, "applications"))
+		}
+	}
+
+	return dirs
+}
+
+// Scan walks the XDG application directories and streams the display name
+// of every visible entry found. The first match for a given name wins, so
+// XDG_DATA_HOME shadows XDG_DATA_DIRS the way the spec expects. The
+// returned map resolves each streamed name back to its expanded Exec=
+// argv, for launchCommand to look up instead of treating the display name
+// itself as an executable.
+func Scan() (<-chan string, map[string][]string) {
+	out := make(chan string)
+	execs := make(map[string][]string)
+
+	go func() {
+		for _, dir := range applicationDirs() {
+			files, err := ioutil.ReadDir(dir)
+			if err != nil {
+				continue
+			}
+			for _, f := range files {
+				if f.IsDir() || !strings.HasSuffix(f.Name(), ".desktop") {
+					continue
+				}
+
+				path := filepath.Join(dir, f.Name())
+				entry, ok := parseFile(path)
+				if !ok {
+					continue
+				}
+				if _, seen := execs[entry.Name]; seen {
+					continue
+				}
+
+				execs[entry.Name] = entry.Exec
+				out <- entry.Name
+			}
+		}
+		close(out)
+	}()
+
+	return out, execs
+}
+
+// parseFile reads the [Desktop Entry] group of a .desktop file and turns
+// it into an Entry, or returns ok=false if the entry shouldn't be shown.
+func parseFile(path string) (Entry, bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Entry{}, false
+	}
+	defer f.Close()
+
+	fields := make(map[string]string)
+	inEntry := false
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			inEntry = line == "[Desktop Entry]"
+			continue
+		}
+		if !inEntry {
+			continue
+		}
+
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		fields[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	}
+
+	return entryFromFields(fields, path)
+}
+
+// entryFromFields applies the Desktop Entry visibility rules and expands
+// Exec= field codes.
+func entryFromFields(fields map[string]string, path string) (Entry, bool) {
+	if fields["NoDisplay"] == "true" || fields["Hidden"] == "true" {
+		return Entry{}, false
+	}
+
+	if current := os.Getenv("XDG_CURRENT_DESKTOP"); current != "" {
+		if only := fields["OnlyShowIn"]; only != "" && !listContains(only, current) {
+			return Entry{}, false
+		}
+		if not := fields["NotShowIn"]; not != "" && listContains(not, current) {
+			return Entry{}, false
+		}
+	}
+
+	name := fields["Name"]
+	if name == "" || fields["Exec"] == "" {
+		return Entry{}, false
+	}
+
+	exec := expandExec(fields["Exec"], fields, path)
+	if fields["Terminal"] == "true" {
+		terminal := os.Getenv("TERMINAL")
+		if terminal == "" {
+			terminal = "xterm"
+		}
+		exec = append([]string{terminal, "-e"}, exec...)
+	}
+
+	return Entry{Name: name, Exec: exec}, true
+}
+
+// listContains reports whether target appears in a semicolon-separated
+// Desktop Entry list value such as OnlyShowIn or NotShowIn.
+func listContains(list, target string) bool {
+	for _, name := range strings.Split(list, ";") {
+		if name == target {
+			return true
+		}
+	}
+	return false
+}
+
+// expandExec resolves the %-field codes of an Exec= line per the Desktop
+// Entry spec into an argv: %f/%F/%u/%U (file and URL arguments) are
+// stripped since yegonesh never launches with one, %i/%c/%k are
+// substituted, and %% is unescaped to a literal %.
+func expandExec(execLine string, fields map[string]string, path string) []string {
+	var out []string
+
+	for _, tok := range strings.Fields(execLine) {
+		switch tok {
+		case "%f", "%F", "%u", "%U":
+			continue
+		case "%i":
+			if icon := fields["Icon"]; icon != "" {
+				out = append(out, "--icon", icon)
+			}
+		case "%c":
+			out = append(out, fields["Name"])
+		case "%k":
+			out = append(out, path)
+		default:
+			out = append(out, strings.ReplaceAll(tok, "%%", "%"))
+		}
+	}
+
+	return out
+}
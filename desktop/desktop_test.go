@@ -0,0 +1,108 @@
+package desktop
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestEntryFromFieldsSkipsHidden(t *testing.T) {
+	for _, hide := range []string{"NoDisplay", "Hidden"} {
+		fields := map[string]string{"Name": "Foo", "Exec": "foo", hide: "true"}
+		if _, ok := entryFromFields(fields, "foo.desktop"); ok {
+			t.Errorf("Expected %s=true to hide the entry", hide)
+		}
+	}
+}
+
+func TestEntryFromFieldsOnlyShowIn(t *testing.T) {
+	defer os.Setenv("XDG_CURRENT_DESKTOP", os.Getenv("XDG_CURRENT_DESKTOP"))
+
+	os.Setenv("XDG_CURRENT_DESKTOP", "GNOME")
+	fields := map[string]string{"Name": "Foo", "Exec": "foo", "OnlyShowIn": "KDE;XFCE"}
+	if _, ok := entryFromFields(fields, "foo.desktop"); ok {
+		t.Error("Expected entry not listed in OnlyShowIn to be hidden")
+	}
+
+	fields["OnlyShowIn"] = "GNOME;KDE"
+	if _, ok := entryFromFields(fields, "foo.desktop"); !ok {
+		t.Error("Expected entry listed in OnlyShowIn to be shown")
+	}
+}
+
+func TestEntryFromFieldsNotShowIn(t *testing.T) {
+	defer os.Setenv("XDG_CURRENT_DESKTOP", os.Getenv("XDG_CURRENT_DESKTOP"))
+
+	os.Setenv("XDG_CURRENT_DESKTOP", "GNOME")
+	fields := map[string]string{"Name": "Foo", "Exec": "foo", "NotShowIn": "GNOME"}
+	if _, ok := entryFromFields(fields, "foo.desktop"); ok {
+		t.Error("Expected entry listed in NotShowIn to be hidden")
+	}
+}
+
+func TestEntryFromFieldsExpandsExec(t *testing.T) {
+	fields := map[string]string{
+		"Name": "Foo Editor",
+		"Exec": "fooedit %f %i --name %c",
+		"Icon": "fooedit-icon",
+	}
+	entry, ok := entryFromFields(fields, "foo.desktop")
+	if !ok {
+		t.Fatal("Expected entry to be shown")
+	}
+	expected := []string{"fooedit", "--icon", "fooedit-icon", "--name", "Foo Editor"}
+	if !reflect.DeepEqual(entry.Exec, expected) {
+		t.Errorf("Expected Exec %v, got %v", expected, entry.Exec)
+	}
+}
+
+func TestEntryFromFieldsTerminal(t *testing.T) {
+	defer os.Setenv("TERMINAL", os.Getenv("TERMINAL"))
+	os.Setenv("TERMINAL", "myterm")
+
+	fields := map[string]string{"Name": "Top", "Exec": "top", "Terminal": "true"}
+	entry, ok := entryFromFields(fields, "top.desktop")
+	if !ok {
+		t.Fatal("Expected entry to be shown")
+	}
+	expected := []string{"myterm", "-e", "top"}
+	if !reflect.DeepEqual(entry.Exec, expected) {
+		t.Errorf("Expected terminal-wrapped Exec %v, got %v", expected, entry.Exec)
+	}
+}
+
+func TestScan(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "yegonesh_desktop")
+	defer os.RemoveAll(dir)
+	appDir := dir + "/applications"
+	os.MkdirAll(appDir, 0755)
+
+	ioutil.WriteFile(appDir+"/foo.desktop", []byte(
+		"[Desktop Entry]\nName=Foo\nExec=foo %U\n"), 0644)
+	ioutil.WriteFile(appDir+"/bar.desktop", []byte(
+		"[Desktop Entry]\nName=Bar\nExec=bar\nNoDisplay=true\n"), 0644)
+	ioutil.WriteFile(appDir+"/baz.txt", []byte("not a desktop file"), 0644)
+
+	oldDataHome := os.Getenv("XDG_DATA_HOME")
+	oldDataDirs := os.Getenv("XDG_DATA_DIRS")
+	defer os.Setenv("XDG_DATA_HOME", oldDataHome)
+	defer os.Setenv("XDG_DATA_DIRS", oldDataDirs)
+	os.Setenv("XDG_DATA_HOME", dir)
+	os.Setenv("XDG_DATA_DIRS", dir+"/empty")
+
+	names, execs := Scan()
+	var result []string
+	for name := range names {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+
+	if !reflect.DeepEqual(result, []string{"Foo"}) {
+		t.Errorf("Expected only the visible Foo entry, got %v", result)
+	}
+	if !reflect.DeepEqual(execs["Foo"], []string{"foo"}) {
+		t.Errorf("Expected Foo to resolve to %v, got %v", []string{"foo"}, execs["Foo"])
+	}
+}
@@ -2,7 +2,6 @@ package main
 
 import (
 	"bufio"
-	"bytes"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -12,11 +11,33 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
+
+	"github.com/Klowner/yegonesh/desktop"
+	"github.com/Klowner/yegonesh/menu"
 )
 
+// maxRecentUses bounds how many recent invocation timestamps are kept per
+// command for frecency scoring.
+const maxRecentUses = 10
+
+const (
+	secondsPerHour  = int64(60 * 60)
+	secondsPerDay   = int64(24) * secondsPerHour
+	secondsPerWeek  = int64(7) * secondsPerDay
+	secondsPerMonth = int64(30) * secondsPerDay
+)
+
+// nowFunc returns the current unix timestamp. It's a var so tests can pin
+// the clock.
+var nowFunc = func() int64 { return time.Now().Unix() }
+
 type Command struct {
-	Name  string
-	Calls uint64
+	Name     string
+	Calls    uint64
+	LastUsed int64
+	Recent   []int64 // bounded, most recent last
+	Score    float64
 }
 
 type Commands []*Command
@@ -32,7 +53,47 @@ func (s Commands) Swap(i, j int) {
 type ByScore struct{ Commands }
 
 func (s ByScore) Less(i, j int) bool {
-	return s.Commands[i].Calls < s.Commands[j].Calls
+	si, sj := s.Commands[i], s.Commands[j]
+	if si.Score != sj.Score {
+		return si.Score < sj.Score
+	}
+	return si.Calls < sj.Calls
+}
+
+// decayWeight implements a Firefox/zsh-z style piecewise frecency decay:
+// recent invocations count far more than stale ones.
+func decayWeight(age int64) float64 {
+	switch {
+	case age < secondsPerHour:
+		return 4
+	case age < secondsPerDay:
+		return 2
+	case age < secondsPerWeek:
+		return 1
+	case age < secondsPerMonth:
+		return 0.5
+	default:
+		return 0
+	}
+}
+
+// frecencyScore sums the decayed weight of every recent invocation relative
+// to now, so the score naturally drifts downward as time passes.
+func frecencyScore(recent []int64, now int64) float64 {
+	var score float64
+	for _, t := range recent {
+		score += decayWeight(now - t)
+	}
+	return score
+}
+
+// pushRecent appends t to recent, dropping the oldest entries past maxRecentUses.
+func pushRecent(recent []int64, t int64) []int64 {
+	recent = append(recent, t)
+	if len(recent) > maxRecentUses {
+		recent = recent[len(recent)-maxRecentUses:]
+	}
+	return recent
 }
 
 func check(e error) {
@@ -89,8 +150,45 @@ func scanPath(path string) <-chan string {
 	return out
 }
 
+// mergeStreams fans multiple string channels into one, closing the result
+// once every input has closed.
+func mergeStreams(streams ...<-chan string) <-chan string {
+	var group sync.WaitGroup
+	out := make(chan string)
+
+	group.Add(len(streams))
+	for _, s := range streams {
+		go func(s <-chan string) {
+			for item := range s {
+				out <- item
+			}
+			group.Done()
+		}(s)
+	}
+
+	go func() {
+		group.Wait()
+		close(out)
+	}()
+
+	return out
+}
+
+// closedStream returns an already-closed channel, used when a source is
+// disabled but callers still expect a channel to merge in.
+func closedStream() <-chan string {
+	out := make(chan string)
+	close(out)
+	return out
+}
+
+// readHistory parses history.tsv. The format is backward-compatible: the
+// original two-column "calls\tname" files still parse, while newer files
+// carry two optional trailing columns, last-used epoch and a comma-joined
+// list of recent-use epochs, used to recompute frecency scores on load.
 func readHistory(path string) Commands {
 	var history Commands
+	now := nowFunc()
 
 	f, err := os.Open(path)
 
@@ -101,10 +199,31 @@ func readHistory(path string) Commands {
 	scanner := bufio.NewScanner(f)
 	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
 		s := strings.Split(line, "\t")
 		calls, err := strconv.ParseInt(s[0], 10, 64)
 		check(err)
-		history = append(history, &Command{s[1], uint64(calls)})
+
+		command := &Command{Name: s[1], Calls: uint64(calls)}
+
+		if len(s) > 2 && s[2] != "" {
+			lastUsed, err := strconv.ParseInt(s[2], 10, 64)
+			check(err)
+			command.LastUsed = lastUsed
+		}
+
+		if len(s) > 3 && s[3] != "" {
+			for _, epoch := range strings.Split(s[3], ",") {
+				t, err := strconv.ParseInt(epoch, 10, 64)
+				check(err)
+				command.Recent = append(command.Recent, t)
+			}
+		}
+
+		command.Score = frecencyScore(command.Recent, now)
+		history = append(history, command)
 	}
 
 	f.Close()
@@ -117,20 +236,28 @@ func writeHistory(path string, commands Commands, lastCommand string) {
 	f, err := os.Create(path)
 	check(err)
 
+	now := nowFunc()
+
 	write := func(command *Command) {
-		fmt.Fprintf(f, "%d\t%s\n", command.Calls, command.Name)
+		recent := make([]string, len(command.Recent))
+		for i, t := range command.Recent {
+			recent[i] = strconv.FormatInt(t, 10)
+		}
+		fmt.Fprintf(f, "%d\t%s\t%d\t%s\n", command.Calls, command.Name, command.LastUsed, strings.Join(recent, ","))
 	}
 
 	for _, command := range commands {
 		if command.Name == lastCommand {
 			command.Calls += 1
+			command.LastUsed = now
+			command.Recent = pushRecent(command.Recent, now)
 			lastCommand = ""
 		}
 		write(command)
 	}
 
 	if lastCommand != "" {
-		write(&Command{lastCommand, 1})
+		write(&Command{Name: lastCommand, Calls: 1, LastUsed: now, Recent: []int64{now}})
 	}
 	f.Close()
 }
@@ -170,49 +297,55 @@ func historyNameStream(commands Commands) <-chan string {
 	return out
 }
 
-func runDmenu(items <-chan string) string {
-	args := dmenuArgs()
-	c := exec.Command("dmenu", args...)
-
-	out := &bytes.Buffer{}
-	c.Stdout = out
-	in, err := c.StdinPipe()
-	check(err)
-
-	c.Start()
-	for cmd := range items {
-		fmt.Fprintf(in, "%s\n", cmd)
-	}
-	in.Close()
-
-	c.Wait()
-
-	// return the command submitted to dmenu
-	return strings.TrimSpace(out.String())
-}
-
-func dmenuArgs() []string {
-	args := os.Args[1:]
-
+// parseMenuArgs splits argv into the requested backend name (from a
+// `--menu=` flag, if present before the `--` separator) and the argv found
+// after `--`, which gets handed to the backend untouched.
+func parseMenuArgs(args []string) (backend string, menuArgs []string) {
 	for i, val := range args {
 		if val == "--" {
-			return args[i+1:]
+			return backend, args[i+1:]
+		}
+		if strings.HasPrefix(val, "--menu=") {
+			backend = strings.TrimPrefix(val, "--menu=")
 		}
 	}
-	return nil
+	return backend, nil
 }
 
-func launchCommand(command string) *exec.Cmd {
-	split := strings.SplitN(command, " ", 2)
-	command = split[0]
-	args := split[1:]
-	path, err := exec.LookPath(command)
+// launchCommand runs command, resolving it through execs first: desktop
+// entries are keyed there by display name, with their already-tokenized
+// Exec= argv, since that can't be exec.LookPath'd directly. Anything not
+// found in execs is treated as a $PATH executable, as before.
+func launchCommand(command string, execs map[string][]string) *exec.Cmd {
+	argv, ok := execs[command]
+	if !ok {
+		argv = strings.SplitN(command, " ", 2)
+	}
+
+	path, err := exec.LookPath(argv[0])
 	check(err)
-	cmd := exec.Command(path, args...)
+	cmd := exec.Command(path, argv[1:]...)
 	cmd.Start()
 	return cmd
 }
 
+// desktopEnabled reports whether .desktop entry indexing is turned on, via
+// a --desktop flag before the `--` separator or $YEGONESH_DESKTOP.
+func desktopEnabled(args []string) bool {
+	if os.Getenv("YEGONESH_DESKTOP") != "" {
+		return true
+	}
+	for _, val := range args {
+		if val == "--" {
+			break
+		}
+		if val == "--desktop" {
+			return true
+		}
+	}
+	return false
+}
+
 func getConfigDir() string {
 	home := os.Getenv("XDG_CONFIG_HOME")
 	var configdir string
@@ -235,15 +368,29 @@ func main() {
 	historyPath := path.Join(configdir, "history.tsv")
 	history := readHistory(historyPath)
 
-	cmd := runDmenu(
+	apps := closedStream()
+	execs := map[string][]string{}
+	if desktopEnabled(os.Args[1:]) {
+		apps, execs = desktop.Scan()
+	}
+
+	backend, menuArgs := parseMenuArgs(os.Args[1:])
+	if backend == "" {
+		backend = menu.Detect()
+	}
+	m, err := menu.New(backend, menuArgs)
+	check(err)
+
+	cmd, err := m.Run(
 		multiplexMenuStreams(
 			historyNameStream(history),
-			executables),
+			mergeStreams(executables, apps)),
 	)
+	check(err)
 
 	if len(cmd) > 0 {
 		// launch the requested process
-		launchCommand(cmd)
+		launchCommand(cmd, execs)
 
 		// update the launch history
 		writeHistory(historyPath, history, cmd)
@@ -93,6 +93,7 @@ func TestScanPath(t *testing.T) {
 }
 
 func TestReadHistory(t *testing.T) {
+	// old two-column files must still parse
 	history := "2\tvim\n3\temacs\n1\tnano\n"
 
 	dir, _ := ioutil.TempDir("", "yegonesh")
@@ -116,7 +117,31 @@ func TestReadHistory(t *testing.T) {
 	}
 }
 
+func TestReadHistoryFrecency(t *testing.T) {
+	oldNow := nowFunc
+	defer func() { nowFunc = oldNow }()
+	nowFunc = func() int64 { return 10000 }
+
+	// vim was used once an hour-ish ago (still weighted), emacs only has a
+	// stale recent entry well past a month and should score lower despite
+	// more total calls.
+	history := "1\tvim\t9000\t9000\n5\temacs\t100\t100\n"
+	dir, _ := ioutil.TempDir("", "yegonesh")
+	defer os.RemoveAll(dir)
+	name := dir + "/history.tsv"
+	ioutil.WriteFile(name, []byte(history), 0644)
+
+	result := readHistory(name)
+	if result[0].Name != "vim" {
+		t.Errorf("Expected vim to rank above emacs due to frecency, got %v", result)
+	}
+}
+
 func TestWriteHistory(t *testing.T) {
+	oldNow := nowFunc
+	defer func() { nowFunc = oldNow }()
+	nowFunc = func() int64 { return 1000 }
+
 	dir, _ := ioutil.TempDir("", "yegonesh")
 	defer os.RemoveAll(dir)
 	name := dir + "/history.tsv"
@@ -129,7 +154,7 @@ func TestWriteHistory(t *testing.T) {
 	f, _ := os.Open(name)
 	defer f.Close()
 	result, _ := ioutil.ReadAll(f)
-	expected := []byte("3\temacs\n3\tvim\n1\tnano\n")
+	expected := []byte("3\temacs\t0\t\n3\tvim\t1000\t1000\n1\tnano\t1000\t1000\n")
 
 	if !bytes.Equal(expected, result) {
 		t.Errorf("Expected history to eq %q, got %q", expected, result)
@@ -160,6 +185,35 @@ func TestMultiplexMenuStreams(t *testing.T) {
 	}
 }
 
+func TestMergeStreams(t *testing.T) {
+	a := make(chan string, 2)
+	b := make(chan string, 2)
+	a <- "vim"
+	a <- "nano"
+	b <- "emacs"
+	close(a)
+	close(b)
+
+	out := mergeStreams(a, b)
+	var result []string
+	for c := range out {
+		result = append(result, c)
+	}
+	sort.Strings(result)
+
+	expected := []string{"emacs", "nano", "vim"}
+	if !reflect.DeepEqual(expected, result) {
+		t.Errorf("Expected merged streams to eq %v, got %v", expected, result)
+	}
+}
+
+func TestClosedStream(t *testing.T) {
+	out := closedStream()
+	if _, ok := <-out; ok {
+		t.Error("Expected closedStream to yield a closed channel")
+	}
+}
+
 func TestHistoryNameStream(t *testing.T) {
 	cmds := Commands{
 		&Command{Name: "emacs"},
@@ -175,20 +229,22 @@ func TestHistoryNameStream(t *testing.T) {
 	}
 }
 
-func TestDMenuArgs(t *testing.T) {
-	os.Args = []string{"yegonesh"}
-	result := dmenuArgs()
-
-	if len(result) != 0 {
-		t.Errorf("Expected arguments list to be empty got %v", result)
+func TestParseMenuArgs(t *testing.T) {
+	backend, args := parseMenuArgs([]string{})
+	if backend != "" || len(args) != 0 {
+		t.Errorf("Expected empty backend and args, got %v %v", backend, args)
 	}
 
-	os.Args = strings.Split("yegonesh -- -b -fn '-xos4-terminus-medium-r-*-*-20-*'", " ")
+	args1 := strings.Split("-- -b -fn '-xos4-terminus-medium-r-*-*-20-*'", " ")
+	backend, args = parseMenuArgs(args1)
 	expected := []string{"-b", "-fn", "'-xos4-terminus-medium-r-*-*-20-*'"}
-	result = dmenuArgs()
+	if backend != "" || !reflect.DeepEqual(args, expected) {
+		t.Errorf("Expected empty backend and args %v, got %v %v", expected, backend, args)
+	}
 
-	if !reflect.DeepEqual(result, expected) {
-		t.Errorf("Expected arguments list to be %v, got %v", expected, result)
+	backend, args = parseMenuArgs([]string{"--menu=rofi", "--", "-p", "run"})
+	if backend != "rofi" || !reflect.DeepEqual(args, []string{"-p", "run"}) {
+		t.Errorf("Expected backend rofi and args [-p run], got %v %v", backend, args)
 	}
 }
 
@@ -211,7 +267,7 @@ func TestGetConfigDir(t *testing.T) {
 
 func TestLaunchCommand(t *testing.T) {
 	name := "echo 'hello, world'"
-	cmd := launchCommand(name)
+	cmd := launchCommand(name, nil)
 	path, _ := exec.LookPath("echo")
 
 	expected := []string{path, "'hello, world'"}
@@ -227,5 +283,40 @@ func TestLaunchCommand(t *testing.T) {
 			t.Error("Expected bogus command to have panicked")
 		}
 	}()
-	launchCommand(name)
+	launchCommand(name, nil)
+}
+
+func TestLaunchCommandResolvesDesktopEntry(t *testing.T) {
+	// a multi-argument argv, the shape a Terminal=true or %i/%c-expanded
+	// Exec= line produces, must reach exec.Command as separate args
+	// rather than being collapsed back into one.
+	execs := map[string][]string{"Hello": {"echo", "hello,", "world"}}
+	cmd := launchCommand("Hello", execs)
+	path, _ := exec.LookPath("echo")
+
+	expected := []string{path, "hello,", "world"}
+	if !reflect.DeepEqual(cmd.Args, expected) {
+		t.Errorf("Expected arguments list to be %v, got %v", expected, cmd.Args)
+	}
+}
+
+func TestDesktopEnabled(t *testing.T) {
+	oldEnv := os.Getenv("YEGONESH_DESKTOP")
+	defer os.Setenv("YEGONESH_DESKTOP", oldEnv)
+	os.Setenv("YEGONESH_DESKTOP", "")
+
+	if desktopEnabled([]string{}) {
+		t.Error("Expected desktop indexing to be disabled by default")
+	}
+	if !desktopEnabled([]string{"--desktop", "--", "-b"}) {
+		t.Error("Expected --desktop flag to enable desktop indexing")
+	}
+	if desktopEnabled([]string{"--", "--desktop"}) {
+		t.Error("Expected --desktop after -- to be a backend argument, not a flag")
+	}
+
+	os.Setenv("YEGONESH_DESKTOP", "1")
+	if !desktopEnabled([]string{}) {
+		t.Error("Expected YEGONESH_DESKTOP to enable desktop indexing")
+	}
 }
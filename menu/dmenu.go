@@ -0,0 +1,11 @@
+package menu
+
+// Dmenu drives dmenu(1) directly. Args is the argv passed after the `--`
+// separator on yegonesh's command line.
+type Dmenu struct {
+	Args []string
+}
+
+func (m Dmenu) Run(items <-chan string) (string, error) {
+	return runDmenuProtocol("dmenu", m.Args, items)
+}
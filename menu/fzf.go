@@ -0,0 +1,14 @@
+package menu
+
+// Fzf drives fzf(1) for terminal use. fzf opens /dev/tty itself for its
+// interactive UI whenever stdin isn't a terminal, so feeding candidates
+// over a pipe and reading the selection back from stdout — exactly what
+// runDmenuProtocol already does — is enough; there's no TTY plumbing to
+// add here.
+type Fzf struct {
+	Args []string
+}
+
+func (m Fzf) Run(items <-chan string) (string, error) {
+	return runDmenuProtocol("fzf", m.Args, items)
+}
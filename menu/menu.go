@@ -0,0 +1,10 @@
+// Package menu abstracts over the various dmenu-like pickers yegonesh can
+// drive: dmenu itself, rofi and wofi in dmenu-compatibility mode, and fzf
+// for terminal use.
+package menu
+
+// Menu runs a picker over a stream of candidates and returns the one the
+// user selected.
+type Menu interface {
+	Run(items <-chan string) (string, error)
+}
@@ -0,0 +1,45 @@
+package menu
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// runDmenuProtocol execs bin with args, feeding items over stdin one per
+// line and returning the trimmed line it writes to stdout. This is the
+// protocol shared by dmenu, `rofi -dmenu` and `wofi --dmenu`.
+func runDmenuProtocol(bin string, args []string, items <-chan string) (string, error) {
+	c := exec.Command(bin, args...)
+
+	out := &bytes.Buffer{}
+	c.Stdout = out
+	in, err := c.StdinPipe()
+	if err != nil {
+		return "", err
+	}
+
+	if err := c.Start(); err != nil {
+		return "", err
+	}
+
+	for item := range items {
+		fmt.Fprintf(in, "%s\n", item)
+	}
+	in.Close()
+
+	if err := c.Wait(); err != nil {
+		// dmenu/rofi/wofi/fzf all exit non-zero when the user dismisses
+		// the menu without picking anything (e.g. Escape) — that's not a
+		// failure, it just means there's no selection.
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return "", err
+		}
+		return "", nil
+	}
+
+	return strings.TrimSpace(out.String()), nil
+}
@@ -0,0 +1,26 @@
+package menu
+
+import "testing"
+
+func TestRunDmenuProtocolNonZeroExitIsNoSelection(t *testing.T) {
+	items := make(chan string)
+	close(items)
+
+	result, err := runDmenuProtocol("false", nil, items)
+	if err != nil {
+		t.Fatalf("Expected a non-zero exit to be treated as no selection, got error: %v", err)
+	}
+	if result != "" {
+		t.Errorf("Expected empty selection, got %q", result)
+	}
+}
+
+func TestRunDmenuProtocolMissingBinary(t *testing.T) {
+	items := make(chan string)
+	close(items)
+
+	_, err := runDmenuProtocol("yegonesh-bogus-menu-binary", nil, items)
+	if err == nil {
+		t.Error("Expected a missing binary to return an error")
+	}
+}
@@ -0,0 +1,43 @@
+package menu
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// backendOrder is the priority auto-detection walks $PATH in when no
+// backend was requested explicitly.
+var backendOrder = []string{"dmenu", "rofi", "wofi", "fzf"}
+
+// New constructs the named backend, passing it the argv found after `--`.
+func New(name string, args []string) (Menu, error) {
+	switch name {
+	case "dmenu":
+		return Dmenu{Args: args}, nil
+	case "rofi":
+		return Rofi{Args: args}, nil
+	case "wofi":
+		return Wofi{Args: args}, nil
+	case "fzf":
+		return Fzf{Args: args}, nil
+	default:
+		return nil, fmt.Errorf("menu: unknown backend %q", name)
+	}
+}
+
+// Detect picks a backend name from $YEGONESH_MENU, falling back to the
+// first backend found on $PATH in backendOrder, and dmenu if none is.
+func Detect() string {
+	if name := os.Getenv("YEGONESH_MENU"); name != "" {
+		return name
+	}
+
+	for _, name := range backendOrder {
+		if _, err := exec.LookPath(name); err == nil {
+			return name
+		}
+	}
+
+	return "dmenu"
+}
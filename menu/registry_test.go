@@ -0,0 +1,47 @@
+package menu
+
+import (
+	"os"
+	"testing"
+)
+
+func TestNewUnknownBackend(t *testing.T) {
+	_, err := New("unknown", nil)
+	if err == nil {
+		t.Error("Expected unknown backend name to return an error")
+	}
+}
+
+func TestNewDmenu(t *testing.T) {
+	m, err := New("dmenu", []string{"-b"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, ok := m.(Dmenu); !ok {
+		t.Errorf("Expected Dmenu, got %T", m)
+	}
+}
+
+func TestDetectEnvOverride(t *testing.T) {
+	old := os.Getenv("YEGONESH_MENU")
+	defer os.Setenv("YEGONESH_MENU", old)
+
+	os.Setenv("YEGONESH_MENU", "wofi")
+	if result := Detect(); result != "wofi" {
+		t.Errorf("Expected wofi, got %v", result)
+	}
+}
+
+func TestDetectFallsBackToDmenu(t *testing.T) {
+	oldMenu := os.Getenv("YEGONESH_MENU")
+	oldPath := os.Getenv("PATH")
+	defer os.Setenv("YEGONESH_MENU", oldMenu)
+	defer os.Setenv("PATH", oldPath)
+
+	os.Setenv("YEGONESH_MENU", "")
+	os.Setenv("PATH", "")
+
+	if result := Detect(); result != "dmenu" {
+		t.Errorf("Expected dmenu fallback, got %v", result)
+	}
+}
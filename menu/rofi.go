@@ -0,0 +1,12 @@
+package menu
+
+// Rofi drives `rofi -dmenu`. Args is appended after `-dmenu`, so callers
+// can still pass rofi-specific flags like `-p` through after `--`.
+type Rofi struct {
+	Args []string
+}
+
+func (m Rofi) Run(items <-chan string) (string, error) {
+	args := append([]string{"-dmenu"}, m.Args...)
+	return runDmenuProtocol("rofi", args, items)
+}
@@ -0,0 +1,11 @@
+package menu
+
+// Wofi drives `wofi --dmenu`, the Wayland dmenu replacement.
+type Wofi struct {
+	Args []string
+}
+
+func (m Wofi) Run(items <-chan string) (string, error) {
+	args := append([]string{"--dmenu"}, m.Args...)
+	return runDmenuProtocol("wofi", args, items)
+}